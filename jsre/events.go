@@ -0,0 +1,109 @@
+package jsre
+
+import (
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/robertkrimen/otto"
+)
+
+// On registers cb to be called, with the arguments passed to Emit, whenever
+// name is emitted. Callbacks run on the VM's event loop goroutine, serialized
+// with all other VM access.
+func (self *JSRE) On(name string, cb otto.Value) {
+	self.evtMu.Lock()
+	defer self.evtMu.Unlock()
+	self.evtListeners[name] = append(self.evtListeners[name], cb)
+}
+
+// Off removes a previously registered callback for name.
+func (self *JSRE) Off(name string, cb otto.Value) {
+	self.evtMu.Lock()
+	defer self.evtMu.Unlock()
+	cbs := self.evtListeners[name]
+	for i, existing := range cbs {
+		if reflect.DeepEqual(existing, cb) {
+			self.evtListeners[name] = append(cbs[:i], cbs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Emit invokes every callback registered for name with args, through the
+// event loop so the calls are serialized with other VM access.
+func (self *JSRE) Emit(name string, args ...interface{}) {
+	self.evtMu.Lock()
+	cbs := make([]otto.Value, len(self.evtListeners[name]))
+	copy(cbs, self.evtListeners[name])
+	self.evtMu.Unlock()
+	if len(cbs) == 0 {
+		return
+	}
+
+	self.do(func(vm *otto.Otto) {
+		for _, cb := range cbs {
+			if _, err := cb.Call(cb, args...); err != nil {
+				glog.V(logger.Error).Infoln("event callback error:", err)
+			}
+		}
+	})
+}
+
+// jsOn is the otto-facing binding for eth.on(name, fn).
+func (self *JSRE) jsOn(call otto.FunctionCall) otto.Value {
+	name, err := call.Argument(0).ToString()
+	if err != nil {
+		return otto.FalseValue()
+	}
+	self.On(name, call.Argument(1))
+	return otto.TrueValue()
+}
+
+// jsOff is the otto-facing binding for eth.off(name, fn).
+func (self *JSRE) jsOff(call otto.FunctionCall) otto.Value {
+	name, err := call.Argument(0).ToString()
+	if err != nil {
+		return otto.FalseValue()
+	}
+	self.Off(name, call.Argument(1))
+	return otto.TrueValue()
+}
+
+// Subscribe wires mux's block and pending-transaction feed into the on/emit
+// bridge: eth.on('block', fn) and eth.on('transaction', fn) start firing as
+// soon as this has been called. Call it once, right after New, with the
+// node's backend event mux. Events are translated to Emit calls from a
+// background goroutine, which is what actually serializes the JS callback
+// against other VM access via evalQueue. The subscription is torn down by
+// Stop().
+func (self *JSRE) Subscribe(mux *event.TypeMux) {
+	sub := mux.Subscribe(core.ChainEvent{}, core.TxPreEvent{})
+	self.muxSub = sub
+	self.muxDone = make(chan struct{})
+	go self.pumpMuxEvents(sub)
+}
+
+// pumpMuxEvents runs until sub.Chan() closes, which Unsubscribe() causes, and
+// closes muxDone on return so Stop() can wait for any in-flight Emit to
+// finish before it halts the event loop that Emit depends on.
+func (self *JSRE) pumpMuxEvents(sub event.Subscription) {
+	defer close(self.muxDone)
+	for evt := range sub.Chan() {
+		switch data := evt.Data.(type) {
+		case core.ChainEvent:
+			self.Emit("block", data.Block)
+		case core.TxPreEvent:
+			self.Emit("transaction", data.Tx)
+		}
+	}
+}
+
+// EmitMiningState notifies eth.on('mining', fn) listeners of a mining
+// start/stop transition. There is no mux feed for this (the miner does not
+// post one), so whatever toggles mining must call this directly.
+func (self *JSRE) EmitMiningState(mining bool) {
+	self.Emit("mining", mining)
+}
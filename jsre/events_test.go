@@ -0,0 +1,79 @@
+package jsre
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// TestSubscribeBridgesBlockEvent verifies that a real core.ChainEvent posted
+// to the mux passed to Subscribe is forwarded, via pumpMuxEvents and Emit, to
+// a JS handler registered with eth.on.
+func TestSubscribeBridgesBlockEvent(t *testing.T) {
+	re := New("")
+	defer re.Stop(false)
+
+	mux := new(event.TypeMux)
+	re.Subscribe(mux)
+
+	re.Run(`
+		var blockEvents = 0;
+		eth.on('block', function(block) { blockEvents++; });
+	`)
+
+	if err := mux.Post(core.ChainEvent{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Post returns once pumpMuxEvents has received the event, but the Emit
+	// it then makes can still be queued on the event loop behind a read of
+	// the counter, so read it until the handler has run or a deadline passes.
+	var count int64
+	deadline := time.Now().Add(time.Second)
+	for {
+		value, err := re.Run("blockEvents")
+		if err != nil {
+			t.Fatal(err)
+		}
+		count, err = value.ToInteger()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if count != 1 {
+		t.Errorf("expected eth.on('block', ...) to observe 1 event, got %d", count)
+	}
+}
+
+// TestEmitMiningState verifies eth.on('mining', fn) observes the state
+// passed to EmitMiningState, the direct call path used since there is no
+// mux feed for mining start/stop.
+func TestEmitMiningState(t *testing.T) {
+	re := New("")
+	defer re.Stop(false)
+
+	re.Run(`
+		var mining = null;
+		eth.on('mining', function(state) { mining = state; });
+	`)
+
+	re.EmitMiningState(true)
+
+	value, err := re.Run("mining")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := value.ToBoolean()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state {
+		t.Error("expected eth.on('mining', ...) to observe true")
+	}
+}
@@ -9,6 +9,7 @@ import (
         "os/exec"
         "path"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/robertkrimen/otto"
 )
 
@@ -25,6 +26,15 @@ type JSRE struct {
 	evalQueue     chan *evalReq
 	stopEventLoop chan bool
 	loopWg        sync.WaitGroup
+
+	modules     map[string]*jsModule
+	modulePath  []string
+	moduleStack []string
+
+	evtMu        sync.Mutex
+	evtListeners map[string][]otto.Value
+	muxSub       event.Subscription
+	muxDone      chan struct{}
 }
 
 // jsTimer is a single timer instance with a callback function
@@ -47,13 +57,21 @@ func New(assetPath string) *JSRE {
 		assetPath:     assetPath,
 		evalQueue:     make(chan *evalReq),
 		stopEventLoop: make(chan bool),
+		modules:       make(map[string]*jsModule),
+		evtListeners:  make(map[string][]otto.Value),
 	}
 	re.loopWg.Add(1)
 	go re.runEventLoop()
 	re.Compile("pp.js", pp_js) // load prettyprint func definition
 	re.Set("loadScript", re.loadScript)
+	re.Set("require", re.require)
+	re.Set("eth", struct{}{})
+	t, _ := re.Get("eth")
+	ethObj := t.Object()
+	ethObj.Set("on", re.jsOn)
+	ethObj.Set("off", re.jsOff)
         re.Set("fs", struct{}{})
-        t, _ := re.Get("fs")
+        t, _ = re.Get("fs")
         fs := t.Object()
         re.Set("process", struct{}{})
         t, _ = re.Get("process")
@@ -209,8 +227,20 @@ func (self *JSRE) do(fn func(*otto.Otto)) {
 
 // stops the event loop before exit, optionally waits for all timers to expire
 func (self *JSRE) Stop(waitForCallbacks bool) {
+	// Unsubscribe and wait for pumpMuxEvents to return before halting the
+	// event loop: it calls Emit, which blocks sending to evalQueue, and
+	// nothing would ever drain that send again once runEventLoop exits.
+	if self.muxSub != nil {
+		self.muxSub.Unsubscribe()
+		<-self.muxDone
+	}
+
 	self.stopEventLoop <- waitForCallbacks
 	self.loopWg.Wait()
+
+	self.evtMu.Lock()
+	self.evtListeners = make(map[string][]otto.Value)
+	self.evtMu.Unlock()
 }
 
 // Exec(file) loads and runs the contents of a file
@@ -0,0 +1,142 @@
+package jsre
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/robertkrimen/otto"
+)
+
+// jsModule tracks a single loaded CommonJS module so repeated requires of the
+// same file share one instance, as in Node.
+type jsModule struct {
+	exports otto.Value
+}
+
+// AddModulePath extends the list of directories require(id) searches for
+// bare module specifiers, in addition to assetPath and $HOME/.geth_modules.
+func (self *JSRE) AddModulePath(paths ...string) {
+	self.modulePath = append(self.modulePath, paths...)
+}
+
+// require implements Node-style module loading for the otto VM: it resolves
+// id to a file, compiles and runs it wrapped as a CommonJS module, and
+// returns module.exports. Already-loaded modules are served from cache;
+// a module that requires itself transitively gets back its partial exports,
+// the same cycle behaviour Node provides.
+func (self *JSRE) require(call otto.FunctionCall) otto.Value {
+	id, err := call.Argument(0).ToString()
+	if err != nil {
+		glog.V(logger.Error).Infoln("require err:", err)
+		return otto.UndefinedValue()
+	}
+
+	file, err := self.resolveModule(id)
+	if err != nil {
+		glog.V(logger.Error).Infoln("require err:", err)
+		return otto.UndefinedValue()
+	}
+
+	exports, err := self.loadModule(call.Otto, file)
+	if err != nil {
+		glog.V(logger.Error).Infoln("require err:", err)
+		return otto.UndefinedValue()
+	}
+	return exports
+}
+
+// resolveModule turns a require() argument into an absolute file path,
+// following Node's rules: relative specifiers resolve against the requiring
+// module's directory, bare specifiers are searched for in assetPath,
+// $HOME/.geth_modules and any paths added via AddModulePath.
+func (self *JSRE) resolveModule(id string) (string, error) {
+	if path.IsAbs(id) {
+		return resolveModuleFile(id)
+	}
+	if strings.HasPrefix(id, "./") || strings.HasPrefix(id, "../") {
+		base := self.assetPath
+		if n := len(self.moduleStack); n > 0 {
+			base = path.Dir(self.moduleStack[n-1])
+		}
+		return resolveModuleFile(path.Join(base, id))
+	}
+
+	dirs := append([]string{self.assetPath}, self.modulePath...)
+	if home := os.Getenv("HOME"); home != "" {
+		dirs = append(dirs, path.Join(home, ".geth_modules"))
+	}
+	for _, dir := range dirs {
+		if file, err := resolveModuleFile(path.Join(dir, id)); err == nil {
+			return file, nil
+		}
+	}
+	return "", fmt.Errorf("cannot find module '%s'", id)
+}
+
+// resolveModuleFile tries candidate, candidate.js and candidate/index.js in
+// turn, returning the first that exists as a regular file.
+func resolveModuleFile(candidate string) (string, error) {
+	for _, try := range []string{candidate, candidate + ".js", path.Join(candidate, "index.js")} {
+		if info, err := os.Stat(try); err == nil && !info.IsDir() {
+			return filepath.Abs(try)
+		}
+	}
+	return "", fmt.Errorf("module not found: %s", candidate)
+}
+
+// loadModule compiles and runs file as a CommonJS module and returns its
+// exports, caching the result by absolute path.
+func (self *JSRE) loadModule(vm *otto.Otto, file string) (otto.Value, error) {
+	if mod, ok := self.modules[file]; ok {
+		return mod.exports, nil
+	}
+
+	source, err := ioutil.ReadFile(file)
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+
+	exportsObj, err := vm.Object("({})")
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+	exportsVal := exportsObj.Value()
+
+	moduleObj, err := vm.Object("({})")
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+	moduleObj.Set("exports", exportsVal)
+
+	mod := &jsModule{exports: exportsVal}
+	self.modules[file] = mod
+
+	wrapped := "(function(module, exports, require, __dirname, __filename) {\n" + string(source) + "\n})"
+	wrapper, err := vm.Run(wrapped)
+	if err != nil {
+		delete(self.modules, file)
+		return otto.UndefinedValue(), err
+	}
+
+	self.moduleStack = append(self.moduleStack, file)
+	_, err = wrapper.Call(wrapper, moduleObj.Value(), exportsVal, self.require, path.Dir(file), file)
+	self.moduleStack = self.moduleStack[:len(self.moduleStack)-1]
+	if err != nil {
+		delete(self.modules, file)
+		return otto.UndefinedValue(), err
+	}
+
+	finalExports, err := moduleObj.Get("exports")
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+	mod.exports = finalExports
+
+	return finalExports, nil
+}
@@ -0,0 +1,82 @@
+package jsre
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func writeModule(t *testing.T, dir, name, contents string) {
+	if err := ioutil.WriteFile(path.Join(dir, name), []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRequireCachesModuleByPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsre-require-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeModule(t, dir, "counter.js", `
+		var n = 0;
+		module.exports = { next: function() { return ++n; } };
+	`)
+
+	re := New(dir)
+	defer re.Stop(false)
+
+	value, err := re.Run(`
+		var a = require('./counter');
+		var b = require('./counter');
+		a.next();
+		a.next();
+		b.next();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := value.ToInteger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("expected requiring the same path twice to share state (n=3), got %d", n)
+	}
+}
+
+func TestRequireCyclePartialExports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsre-require-cycle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeModule(t, dir, "a.js", `
+		exports.loaded = false;
+		var b = require('./b');
+		exports.bSawLoaded = b.aWasLoadedWhenBRan;
+		exports.loaded = true;
+	`)
+	writeModule(t, dir, "b.js", `
+		var a = require('./a');
+		exports.aWasLoadedWhenBRan = a.loaded;
+	`)
+
+	re := New(dir)
+	defer re.Stop(false)
+
+	value, err := re.Run(`require('./a').bSawLoaded`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sawLoaded, err := value.ToBoolean()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sawLoaded {
+		t.Error("expected b, loaded mid-cycle, to observe a's partial (not-yet-finished) exports")
+	}
+}
@@ -3,8 +3,6 @@ package ws
 import (
 	"encoding/json"
 
-	"github.com/ethereum/go-ethereum/logger"
-	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/xeth"
 )
 
@@ -15,6 +13,18 @@ const (
 	MinerStop           = "miner_stop"
 	MinerHashrate       = "miner_hashrate"
 	ImportPresaleWallet = "import_presale_wallet"
+	AdminSuggestPeer    = "admin_suggestPeer"
+	AdminStartRPC       = "admin_startRPC"
+	AdminStopRPC        = "admin_stopRPC"
+	AdminNodeInfo       = "admin_nodeInfo"
+	AdminPeers          = "admin_peers"
+	AdminNewAccount     = "admin_newAccount"
+	AdminUnlock         = "admin_unlock"
+	AdminImportChain    = "admin_importChain"
+	AdminExportChain    = "admin_exportChain"
+	AdminDumpBlock      = "admin_dumpBlock"
+	Subscribe           = "subscribe"
+	Unsubscribe         = "unsubscribe"
 )
 
 func init() {
@@ -24,42 +34,62 @@ func init() {
 	actions[MinerStop] = minerStop
 	actions[MinerHashrate] = minerHashrate
 	actions[ImportPresaleWallet] = importPresaleWallet
+	actions[AdminSuggestPeer] = adminSuggestPeer
+	actions[AdminStartRPC] = adminStartRPC
+	actions[AdminStopRPC] = adminStopRPC
+	actions[AdminNodeInfo] = adminNodeInfo
+	actions[AdminPeers] = adminPeers
+	actions[AdminNewAccount] = adminNewAccount
+	actions[AdminUnlock] = adminUnlock
+	actions[AdminImportChain] = adminImportChain
+	actions[AdminExportChain] = adminExportChain
+	actions[AdminDumpBlock] = adminDumpBlock
+	actions[Subscribe] = subscribe
+	actions[Unsubscribe] = unsubscribe
 }
 
-// websocket API stateless handler type
-type RequestHandler func(eth *xeth.XEth, req *WSRequest, res *interface{}) error
+// RequestHandler is a websocket API handler. It is no longer stateless: conn
+// carries the state of the connection the request arrived on, which handlers
+// that create or tear down subscriptions need access to.
+type RequestHandler func(eth *xeth.XEth, conn *Conn, req *WSRequest, res *interface{}) error
 
-func quit(eth *xeth.XEth, req *WSRequest, res *interface{}) error {
+func quit(eth *xeth.XEth, conn *Conn, req *WSRequest, res *interface{}) error {
 	eth.StopBackend()
 	return nil
 }
 
-func minerStart(eth *xeth.XEth, wsreq *WSRequest, wsres *interface{}) error {
+func minerStart(eth *xeth.XEth, conn *Conn, wsreq *WSRequest, wsres *interface{}) error {
 	var req MinerStartRequest
-	json.Unmarshal(wsreq.Params, &req)
+	if err := json.Unmarshal(wsreq.Params, &req); err != nil {
+		return err
+	}
 
 	if eth.SetMining(true, req.NumThreads) {
+		broadcastMiningStatus(true)
 		return nil
 	}
 	return MinerNotStarted
 }
 
-func minerStop(eth *xeth.XEth, wsreq *WSRequest, wsres *interface{}) error {
+func minerStop(eth *xeth.XEth, conn *Conn, wsreq *WSRequest, wsres *interface{}) error {
 	var req MinerStopRequest
-	json.Unmarshal(wsreq.Params, &req)
+	if err := json.Unmarshal(wsreq.Params, &req); err != nil {
+		return err
+	}
 
 	if !eth.SetMining(false, req.NumThreads) {
+		broadcastMiningStatus(false)
 		return nil
 	}
 	return MinerNotStopped
 }
 
-func minerHashrate(eth *xeth.XEth, wsreq *WSRequest, wsres *interface{}) error {
+func minerHashrate(eth *xeth.XEth, conn *Conn, wsreq *WSRequest, wsres *interface{}) error {
 	*wsres = &MinerHashrateResponse{Hashrate: eth.HashRate()}
 	return nil
 }
 
-func importPresaleWallet(eth *xeth.XEth, req *WSRequest, res *interface{}) error {
+func importPresaleWallet(eth *xeth.XEth, conn *Conn, req *WSRequest, res *interface{}) error {
 	var params ImportPresaleWalletRequest
 	err := json.Unmarshal(req.Params, &params)
 	if err != nil {
@@ -73,3 +103,114 @@ func importPresaleWallet(eth *xeth.XEth, req *WSRequest, res *interface{}) error
 
 	return err
 }
+
+func adminSuggestPeer(eth *xeth.XEth, conn *Conn, wsreq *WSRequest, wsres *interface{}) error {
+	var req AdminSuggestPeerRequest
+	if err := json.Unmarshal(wsreq.Params, &req); err != nil {
+		return err
+	}
+
+	if err := eth.SuggestPeer(req.Url); err != nil {
+		return PeerNotSuggested
+	}
+	*wsres = &AdminSuggestPeerResponse{Success: true}
+	return nil
+}
+
+func adminStartRPC(eth *xeth.XEth, conn *Conn, wsreq *WSRequest, wsres *interface{}) error {
+	var req AdminStartRPCRequest
+	if err := json.Unmarshal(wsreq.Params, &req); err != nil {
+		return err
+	}
+
+	if !eth.StartRPC(req.ListenAddress, int(req.ListenPort), req.CorsDomain, req.Apis) {
+		return RPCNotStarted
+	}
+	*wsres = &AdminStartRPCResponse{Success: true}
+	return nil
+}
+
+func adminStopRPC(eth *xeth.XEth, conn *Conn, wsreq *WSRequest, wsres *interface{}) error {
+	if !eth.StopRPC() {
+		return RPCNotStopped
+	}
+	*wsres = &AdminStopRPCResponse{Success: true}
+	return nil
+}
+
+func adminNodeInfo(eth *xeth.XEth, conn *Conn, wsreq *WSRequest, wsres *interface{}) error {
+	*wsres = &AdminNodeInfoResponse{NodeInfo: eth.NodeInfo()}
+	return nil
+}
+
+func adminPeers(eth *xeth.XEth, conn *Conn, wsreq *WSRequest, wsres *interface{}) error {
+	*wsres = &AdminPeersResponse{Peers: eth.PeersInfo()}
+	return nil
+}
+
+func adminNewAccount(eth *xeth.XEth, conn *Conn, wsreq *WSRequest, wsres *interface{}) error {
+	var req AdminNewAccountRequest
+	if err := json.Unmarshal(wsreq.Params, &req); err != nil {
+		return err
+	}
+
+	acc, err := eth.NewAccount(req.Password)
+	if err != nil {
+		return AccountNotCreated
+	}
+	*wsres = &AdminNewAccountResponse{Address: acc.Address}
+	return nil
+}
+
+func adminUnlock(eth *xeth.XEth, conn *Conn, wsreq *WSRequest, wsres *interface{}) error {
+	var req AdminUnlockRequest
+	if err := json.Unmarshal(wsreq.Params, &req); err != nil {
+		return err
+	}
+
+	if err := eth.UnlockAccount(req.Address, req.Password, req.Duration); err != nil {
+		return AccountNotUnlocked
+	}
+	*wsres = &AdminUnlockResponse{Success: true}
+	return nil
+}
+
+func adminImportChain(eth *xeth.XEth, conn *Conn, wsreq *WSRequest, wsres *interface{}) error {
+	var req AdminImportChainRequest
+	if err := json.Unmarshal(wsreq.Params, &req); err != nil {
+		return err
+	}
+
+	if err := eth.ImportChain(req.File); err != nil {
+		return ChainNotImported
+	}
+	*wsres = &AdminImportChainResponse{Success: true}
+	return nil
+}
+
+func adminExportChain(eth *xeth.XEth, conn *Conn, wsreq *WSRequest, wsres *interface{}) error {
+	var req AdminExportChainRequest
+	if err := json.Unmarshal(wsreq.Params, &req); err != nil {
+		return err
+	}
+
+	if err := eth.ExportChain(req.File); err != nil {
+		return ChainNotExported
+	}
+	*wsres = &AdminExportChainResponse{Success: true}
+	return nil
+}
+
+func adminDumpBlock(eth *xeth.XEth, conn *Conn, wsreq *WSRequest, wsres *interface{}) error {
+	var req AdminDumpBlockRequest
+	if err := json.Unmarshal(wsreq.Params, &req); err != nil {
+		return err
+	}
+
+	dump, err := eth.DumpBlock(req.Number)
+	if err != nil {
+		return BlockNotFound
+	}
+	*wsres = &AdminDumpBlockResponse{Dump: dump}
+	return nil
+}
@@ -0,0 +1,145 @@
+package ws
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"time"
+
+	"github.com/ethereum/go-ethereum/xeth"
+)
+
+// AuthMethod is the handshake method a client must send before any other
+// request is served once the connection has a SecurityToken configured.
+const AuthMethod = "auth"
+
+const (
+	maxFailedAuthAttempts = 5
+	authLockoutDuration   = 30 * time.Second
+
+	// AdminRole is required by the methods listed in acl.
+	AdminRole = "admin"
+)
+
+type AuthRequest struct {
+	Token string `json:"token"`
+}
+
+type AuthResponse struct {
+	Success bool `json:"success"`
+}
+
+// acl maps a method name to the roles allowed to call it. Methods absent
+// from acl, such as miner_hashrate, require no elevated role.
+var acl = map[string][]string{
+	Quit:                {AdminRole},
+	MinerStart:          {AdminRole},
+	MinerStop:           {AdminRole},
+	ImportPresaleWallet: {AdminRole},
+	AdminSuggestPeer:    {AdminRole},
+	AdminStartRPC:       {AdminRole},
+	AdminStopRPC:        {AdminRole},
+	AdminNodeInfo:       {AdminRole},
+	AdminPeers:          {AdminRole},
+	AdminNewAccount:     {AdminRole},
+	AdminUnlock:         {AdminRole},
+	AdminImportChain:    {AdminRole},
+	AdminExportChain:    {AdminRole},
+	AdminDumpBlock:      {AdminRole},
+}
+
+func init() {
+	actions[AuthMethod] = auth
+}
+
+func auth(eth *xeth.XEth, conn *Conn, wsreq *WSRequest, wsres *interface{}) error {
+	if conn.authRateLimited() {
+		return TooManyFailedAuthAttempts
+	}
+
+	var req AuthRequest
+	if err := json.Unmarshal(wsreq.Params, &req); err != nil {
+		return err
+	}
+
+	if conn.securityToken == "" || !tokenEquals(req.Token, conn.securityToken) {
+		conn.recordFailedAuth()
+		return AuthenticationFailed
+	}
+
+	conn.authenticate(AdminRole)
+	*wsres = &AuthResponse{Success: true}
+	return nil
+}
+
+// tokenEquals compares two tokens in constant time so auth cannot be brute
+// forced via response-time side channels.
+func tokenEquals(given, want string) bool {
+	if len(given) != len(want) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(given), []byte(want)) == 1
+}
+
+// Authorize reports whether conn, given its current authentication state, is
+// allowed to invoke method. The transport must call this before dispatching
+// to actions[method]; it is what enforces both "auth must come first" and
+// the per-method ACL.
+func Authorize(conn *Conn, method string) error {
+	if method == AuthMethod {
+		return nil
+	}
+	if conn.securityToken == "" {
+		return nil
+	}
+
+	if !conn.isAuthenticated() {
+		return AuthenticationRequired
+	}
+	roles, restricted := acl[method]
+	if !restricted {
+		return nil
+	}
+
+	role := conn.currentRole()
+	for _, allowed := range roles {
+		if allowed == role {
+			return nil
+		}
+	}
+	return Forbidden
+}
+
+func (c *Conn) isAuthenticated() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.authenticated
+}
+
+func (c *Conn) currentRole() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.role
+}
+
+func (c *Conn) authRateLimited() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.failedAuthAttempts >= maxFailedAuthAttempts && time.Now().Before(c.authLockedUntil)
+}
+
+func (c *Conn) recordFailedAuth() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failedAuthAttempts++
+	if c.failedAuthAttempts >= maxFailedAuthAttempts {
+		c.authLockedUntil = time.Now().Add(authLockoutDuration)
+	}
+}
+
+func (c *Conn) authenticate(role string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.authenticated = true
+	c.role = role
+	c.failedAuthAttempts = 0
+}
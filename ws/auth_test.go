@@ -0,0 +1,76 @@
+package ws
+
+import "testing"
+
+func TestAuthorizeNoSecurityTokenAllowsEverything(t *testing.T) {
+	conn := NewConn("")
+	if err := Authorize(conn, MinerStart); err != nil {
+		t.Errorf("expected no security token to bypass the ACL, got %v", err)
+	}
+}
+
+func TestAuthorizeRequiresAuthenticationForRestrictedMethod(t *testing.T) {
+	conn := NewConn("secret")
+	if err := Authorize(conn, MinerStart); err != AuthenticationRequired {
+		t.Errorf("expected AuthenticationRequired, got %v", err)
+	}
+	if err := Authorize(conn, MinerHashrate); err != AuthenticationRequired {
+		t.Errorf("expected an unrestricted method to also require auth first, got %v", err)
+	}
+}
+
+func TestAuthorizeRestrictsAdminInfoMethods(t *testing.T) {
+	conn := NewConn("secret")
+	for _, method := range []string{AdminNodeInfo, AdminPeers} {
+		if err := Authorize(conn, method); err != AuthenticationRequired {
+			t.Errorf("%s: expected AuthenticationRequired for an unauthenticated caller, got %v", method, err)
+		}
+	}
+}
+
+func TestAuthorizeAllowsAfterAuthenticate(t *testing.T) {
+	conn := NewConn("secret")
+	conn.authenticate(AdminRole)
+	if err := Authorize(conn, MinerStart); err != nil {
+		t.Errorf("expected an authenticated admin to be allowed, got %v", err)
+	}
+}
+
+func TestAuthHandshakeRejectsWrongToken(t *testing.T) {
+	conn := NewConn("secret")
+	var res interface{}
+	req := &WSRequest{Params: []byte(`{"token":"wrong"}`)}
+	err := auth(nil, conn, req, &res)
+	if err != AuthenticationFailed {
+		t.Errorf("expected AuthenticationFailed, got %v", err)
+	}
+	if conn.isAuthenticated() {
+		t.Error("expected a failed auth attempt to not authenticate the connection")
+	}
+}
+
+func TestAuthHandshakeAcceptsRightToken(t *testing.T) {
+	conn := NewConn("secret")
+	var res interface{}
+	req := &WSRequest{Params: []byte(`{"token":"secret"}`)}
+	if err := auth(nil, conn, req, &res); err != nil {
+		t.Fatalf("expected auth to succeed, got %v", err)
+	}
+	if !conn.isAuthenticated() {
+		t.Error("expected a successful auth attempt to authenticate the connection")
+	}
+}
+
+func TestAuthRateLimitsAfterRepeatedFailures(t *testing.T) {
+	conn := NewConn("secret")
+	var res interface{}
+	req := &WSRequest{Params: []byte(`{"token":"wrong"}`)}
+	for i := 0; i < maxFailedAuthAttempts; i++ {
+		if err := auth(nil, conn, req, &res); err != AuthenticationFailed {
+			t.Fatalf("attempt %d: expected AuthenticationFailed, got %v", i, err)
+		}
+	}
+	if err := auth(nil, conn, req, &res); err != TooManyFailedAuthAttempts {
+		t.Errorf("expected the connection to be rate limited after %d failures, got %v", maxFailedAuthAttempts, err)
+	}
+}
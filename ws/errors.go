@@ -0,0 +1,33 @@
+package ws
+
+// wsError is a ws-layer error carrying a stable numeric code that is surfaced
+// to clients alongside its message, similar in spirit to JSON-RPC error codes
+// but kept in a range of our own so it doesn't collide with the codec layer.
+type wsError struct {
+	code    int
+	message string
+}
+
+func (e *wsError) Error() string { return e.message }
+func (e *wsError) Code() int     { return e.code }
+
+// Error table for ws handlers. MinerNotStarted and MinerNotStopped are
+// declared in server.go, next to the transport.
+var (
+	PeerNotSuggested   = &wsError{-30003, "peer could not be added"}
+	RPCNotStarted      = &wsError{-30004, "RPC server could not be started"}
+	RPCNotStopped      = &wsError{-30005, "RPC server could not be stopped"}
+	AccountNotCreated  = &wsError{-30006, "account could not be created"}
+	AccountNotUnlocked = &wsError{-30007, "account could not be unlocked"}
+	ChainNotImported   = &wsError{-30008, "chain could not be imported"}
+	ChainNotExported   = &wsError{-30009, "chain could not be exported"}
+	BlockNotFound      = &wsError{-30010, "block could not be found"}
+
+	UnknownSubscriptionType = &wsError{-30011, "unknown subscription type"}
+	SubscriptionNotFound    = &wsError{-30012, "subscription not found"}
+
+	AuthenticationFailed      = &wsError{-30013, "authentication failed"}
+	AuthenticationRequired    = &wsError{-30014, "method requires authentication"}
+	Forbidden                 = &wsError{-30015, "method requires a higher role"}
+	TooManyFailedAuthAttempts = &wsError{-30016, "too many failed authentication attempts, try again later"}
+)
@@ -0,0 +1,143 @@
+package ws
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/xeth"
+)
+
+// Standard JSON-RPC 2.0 error codes. Application errors (see errors.go) all
+// live above -32000 and so never collide with these.
+const (
+	JSONRPCParseError     = -32700
+	JSONRPCInvalidRequest = -32600
+	JSONRPCMethodNotFound = -32601
+	JSONRPCInvalidParams  = -32602
+	JSONRPCInternalError  = -32603
+)
+
+// JSONRPCRequest is the ProtocolJSONRPC2 counterpart of WSRequest.
+type JSONRPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Id      interface{}     `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// JSONRPCResponse is the ProtocolJSONRPC2 counterpart of
+// WSSuccessResponse/WSErrorResponse: exactly one of Result/Error is set.
+type JSONRPCResponse struct {
+	JSONRPC string        `json:"jsonrpc"`
+	Id      interface{}   `json:"id"`
+	Result  interface{}   `json:"result,omitempty"`
+	Error   *JSONRPCError `json:"error,omitempty"`
+}
+
+type JSONRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (r *JSONRPCRequest) toWSRequest() *WSRequest {
+	return &WSRequest{Id: r.Id, Method: r.Method, Params: r.Params}
+}
+
+func newJSONRPCErrorResponse(id interface{}, code int, message string) *JSONRPCResponse {
+	return &JSONRPCResponse{JSONRPC: "2.0", Id: id, Error: &JSONRPCError{Code: code, Message: message}}
+}
+
+// errToJSONRPCResponse maps a handler error to a response. It preserves the
+// application error code from errors.go (via its Code() method) when one is
+// present, maps a raw params-decoding failure to JSONRPCInvalidParams, and
+// falls back to the generic internal-error code for anything else.
+func errToJSONRPCResponse(id interface{}, err error) *JSONRPCResponse {
+	if coder, ok := err.(interface{ Code() int }); ok {
+		return newJSONRPCErrorResponse(id, coder.Code(), err.Error())
+	}
+	if isParamsError(err) {
+		return newJSONRPCErrorResponse(id, JSONRPCInvalidParams, err.Error())
+	}
+	return newJSONRPCErrorResponse(id, JSONRPCInternalError, err.Error())
+}
+
+// isParamsError reports whether err came from decoding a handler's Params,
+// i.e. whether it should surface to the client as Invalid Params rather
+// than a generic Internal Error.
+func isParamsError(err error) bool {
+	switch err.(type) {
+	case *json.UnmarshalTypeError, *json.SyntaxError, *json.InvalidUnmarshalError:
+		return true
+	}
+	return false
+}
+
+// DispatchJSONRPC serves one ProtocolJSONRPC2 request, which may be a single
+// request object or a batch array, reusing the actions registry unchanged.
+// It returns a single *JSONRPCResponse, a []*JSONRPCResponse for a batch, or
+// nil when nothing should be written back (a lone notification, or a batch
+// made up entirely of notifications).
+func DispatchJSONRPC(eth *xeth.XEth, conn *Conn, raw json.RawMessage) interface{} {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var reqs []json.RawMessage
+		if err := json.Unmarshal(trimmed, &reqs); err != nil {
+			return newJSONRPCErrorResponse(nil, JSONRPCParseError, "parse error")
+		}
+		if len(reqs) == 0 {
+			return newJSONRPCErrorResponse(nil, JSONRPCInvalidRequest, "invalid request")
+		}
+		responses := make([]*JSONRPCResponse, 0, len(reqs))
+		for _, req := range reqs {
+			if resp := dispatchJSONRPCOne(eth, conn, req); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			return nil
+		}
+		return responses
+	}
+	return dispatchJSONRPCOne(eth, conn, trimmed)
+}
+
+// dispatchJSONRPCOne serves a single request object. It returns nil when the
+// request is a notification (no "id" member), per the JSON-RPC 2.0 spec
+// barring the server from replying to those, even on error.
+func dispatchJSONRPCOne(eth *xeth.XEth, conn *Conn, raw json.RawMessage) *JSONRPCResponse {
+	var req JSONRPCRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return newJSONRPCErrorResponse(nil, JSONRPCParseError, "parse error")
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return newJSONRPCErrorResponse(req.Id, JSONRPCInvalidRequest, "invalid request")
+	}
+	isNotification := req.Id == nil
+
+	resp := serveJSONRPC(eth, conn, &req)
+	if isNotification {
+		return nil
+	}
+	return resp
+}
+
+func serveJSONRPC(eth *xeth.XEth, conn *Conn, req *JSONRPCRequest) *JSONRPCResponse {
+	if err := Authorize(conn, req.Method); err != nil {
+		return errToJSONRPCResponse(req.Id, err)
+	}
+
+	handler, ok := actions[req.Method]
+	if !ok {
+		return newJSONRPCErrorResponse(req.Id, JSONRPCMethodNotFound, "method not found")
+	}
+
+	var result interface{}
+	if err := handler(eth, conn, req.toWSRequest(), &result); err != nil {
+		return errToJSONRPCResponse(req.Id, err)
+	}
+	if result == nil {
+		// Keep "result" on the wire (as null) despite omitempty.
+		result = json.RawMessage("null")
+	}
+	return &JSONRPCResponse{JSONRPC: "2.0", Id: req.Id, Result: result}
+}
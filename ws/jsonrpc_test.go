@@ -0,0 +1,109 @@
+package ws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/xeth"
+)
+
+const testMethod = "test_echo"
+
+func init() {
+	actions[testMethod] = func(eth *xeth.XEth, conn *Conn, req *WSRequest, res *interface{}) error {
+		*res = "ok"
+		return nil
+	}
+}
+
+func TestDispatchJSONRPCSingleSuccess(t *testing.T) {
+	raw := json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"` + testMethod + `"}`)
+	resp, ok := DispatchJSONRPC(nil, NewConn(""), raw).(*JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected *JSONRPCResponse, got %T", resp)
+	}
+	if resp.Error != nil {
+		t.Fatalf("unexpected error response: %+v", resp.Error)
+	}
+	if resp.Result != "ok" {
+		t.Errorf("expected result %q, got %v", "ok", resp.Result)
+	}
+}
+
+func TestDispatchJSONRPCMethodNotFound(t *testing.T) {
+	raw := json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"does_not_exist"}`)
+	resp, ok := DispatchJSONRPC(nil, NewConn(""), raw).(*JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected *JSONRPCResponse, got %T", resp)
+	}
+	if resp.Error == nil || resp.Error.Code != JSONRPCMethodNotFound {
+		t.Errorf("expected method-not-found (%d), got %+v", JSONRPCMethodNotFound, resp.Error)
+	}
+}
+
+func TestDispatchJSONRPCInvalidRequest(t *testing.T) {
+	raw := json.RawMessage(`{"jsonrpc":"2.0","id":1}`)
+	resp, ok := DispatchJSONRPC(nil, NewConn(""), raw).(*JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected *JSONRPCResponse, got %T", resp)
+	}
+	if resp.Error == nil || resp.Error.Code != JSONRPCInvalidRequest {
+		t.Errorf("expected invalid-request (%d), got %+v", JSONRPCInvalidRequest, resp.Error)
+	}
+}
+
+func TestDispatchJSONRPCInvalidParams(t *testing.T) {
+	raw := json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"` + MinerStart + `","params":"not an object"}`)
+	resp, ok := DispatchJSONRPC(nil, NewConn(""), raw).(*JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected *JSONRPCResponse, got %T", resp)
+	}
+	if resp.Error == nil || resp.Error.Code != JSONRPCInvalidParams {
+		t.Errorf("expected invalid-params (%d), got %+v", JSONRPCInvalidParams, resp.Error)
+	}
+}
+
+func TestDispatchJSONRPCNotificationSuppressed(t *testing.T) {
+	raw := json.RawMessage(`{"jsonrpc":"2.0","method":"` + testMethod + `"}`)
+	if resp := DispatchJSONRPC(nil, NewConn(""), raw); resp != nil {
+		t.Errorf("expected no response for a notification, got %+v", resp)
+	}
+}
+
+func TestDispatchJSONRPCEmptyBatchRejected(t *testing.T) {
+	raw := json.RawMessage(`[]`)
+	resp, ok := DispatchJSONRPC(nil, NewConn(""), raw).(*JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected a single *JSONRPCResponse for an empty batch, got %T", resp)
+	}
+	if resp.Error == nil || resp.Error.Code != JSONRPCInvalidRequest {
+		t.Errorf("expected invalid-request (%d), got %+v", JSONRPCInvalidRequest, resp.Error)
+	}
+}
+
+func TestDispatchJSONRPCBatchFiltersNotifications(t *testing.T) {
+	raw := json.RawMessage(`[
+		{"jsonrpc":"2.0","id":1,"method":"` + testMethod + `"},
+		{"jsonrpc":"2.0","method":"` + testMethod + `"}
+	]`)
+	responses, ok := DispatchJSONRPC(nil, NewConn(""), raw).([]*JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected []*JSONRPCResponse, got %T", responses)
+	}
+	if len(responses) != 1 {
+		t.Fatalf("expected the notification to be dropped, got %d responses", len(responses))
+	}
+	if responses[0].Id != float64(1) {
+		t.Errorf("expected the surviving response to be for id 1, got %v", responses[0].Id)
+	}
+}
+
+func TestDispatchJSONRPCBatchAllNotificationsSuppressed(t *testing.T) {
+	raw := json.RawMessage(`[
+		{"jsonrpc":"2.0","method":"` + testMethod + `"},
+		{"jsonrpc":"2.0","method":"` + testMethod + `"}
+	]`)
+	if resp := DispatchJSONRPC(nil, NewConn(""), raw); resp != nil {
+		t.Errorf("expected no response for an all-notification batch, got %+v", resp)
+	}
+}
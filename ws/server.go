@@ -0,0 +1,152 @@
+package ws
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/xeth"
+)
+
+// actions maps a WS method name to the handler that serves it. Populated by
+// the init() functions alongside each handler's definition (actions.go,
+// auth.go, subscriptions.go).
+var actions = map[string]RequestHandler{}
+
+// MinerNotStarted and MinerNotStopped are declared here, next to the
+// transport that actually surfaces them to clients, rather than with the
+// rest of the error table in errors.go.
+var (
+	MinerNotStarted = &wsError{-30001, "miner could not be started"}
+	MinerNotStopped = &wsError{-30002, "miner could not be stopped"}
+)
+
+// Start serves the ws API on cfg.ListenAddress:ListenPort until the process
+// exits. The wire codec served is selected by cfg.Protocol.
+func Start(cfg *Config, eth *xeth.XEth) error {
+	tlsConfig, err := cfg.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	addr := cfg.ListenAddress + ":" + strconv.FormatUint(uint64(cfg.ListenPort), 10)
+	handler := websocket.Handler(func(ws *websocket.Conn) {
+		serveConn(cfg, eth, ws)
+	})
+
+	server := &http.Server{Addr: addr, Handler: handler}
+	if tlsConfig != nil {
+		server.TLSConfig = tlsConfig
+		return server.ListenAndServeTLS("", "")
+	}
+	return server.ListenAndServe()
+}
+
+// serveConn drives a single accepted connection until it is closed: incoming
+// frames are dispatched to the actions registry, and outgoing subscription
+// notifications are drained from conn.Notifications() and written back.
+// Request/response replies and pushed notifications both write to the same
+// underlying socket from separate goroutines, so every write goes through
+// writeMu to keep frames from interleaving.
+func serveConn(cfg *Config, eth *xeth.XEth, ws *websocket.Conn) {
+	conn := NewConn(cfg.SecurityToken)
+	defer conn.Close()
+
+	var writeMu sync.Mutex
+	go pumpNotifications(ws, conn, &writeMu)
+
+	for {
+		var raw json.RawMessage
+		if err := websocket.JSON.Receive(ws, &raw); err != nil {
+			if err != io.EOF {
+				glog.V(logger.Debug).Infoln("ws read error:", err)
+			}
+			return
+		}
+
+		resp := dispatch(cfg, eth, conn, raw)
+		if resp == nil {
+			continue
+		}
+		writeMu.Lock()
+		err := websocket.JSON.Send(ws, resp)
+		writeMu.Unlock()
+		if err != nil {
+			glog.V(logger.Debug).Infoln("ws write error:", err)
+			return
+		}
+	}
+}
+
+// dispatch serves one request frame, picking the wire codec based on
+// cfg.Protocol. It returns nil when nothing should be written back, which is
+// only possible in ProtocolJSONRPC2 (a notification, or an all-notification
+// batch).
+func dispatch(cfg *Config, eth *xeth.XEth, conn *Conn, raw json.RawMessage) interface{} {
+	if cfg.Protocol == ProtocolJSONRPC2 {
+		return DispatchJSONRPC(eth, conn, raw)
+	}
+	return dispatchWSJSON(eth, conn, raw)
+}
+
+// dispatchWSJSON serves one ProtocolWSJSON request, the legacy framing used
+// before ProtocolJSONRPC2 compatibility mode was added.
+func dispatchWSJSON(eth *xeth.XEth, conn *Conn, raw json.RawMessage) interface{} {
+	var req WSRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return errToWSResponse(nil, "", err)
+	}
+
+	if err := Authorize(conn, req.Method); err != nil {
+		return errToWSResponse(req.Id, req.Method, err)
+	}
+
+	handler, ok := actions[req.Method]
+	if !ok {
+		return errToWSResponse(req.Id, req.Method, &wsError{JSONRPCMethodNotFound, "method not found"})
+	}
+
+	var result interface{}
+	if err := handler(eth, conn, &req, &result); err != nil {
+		return errToWSResponse(req.Id, req.Method, err)
+	}
+	return &WSSuccessResponse{Id: req.Id, WsVersion: "2.0", Method: req.Method, Result: result}
+}
+
+// errToWSResponse maps a handler error to a WSErrorResponse, preserving the
+// application error code from errors.go (via its Code() method) when one is
+// present.
+func errToWSResponse(id interface{}, method string, err error) *WSErrorResponse {
+	code := JSONRPCInternalError
+	if coder, ok := err.(interface{ Code() int }); ok {
+		code = coder.Code()
+	}
+	return &WSErrorResponse{
+		Id:        id,
+		WsVersion: "2.0",
+		Method:    method,
+		Error:     &WSErrorObject{Code: code, Message: err.Error()},
+	}
+}
+
+// pumpNotifications writes every subscription notification pushed to conn
+// out to ws until the connection's notify channel is torn down by Close().
+// writeMu is shared with serveConn's request/response loop so the two
+// goroutines never write to ws at the same time.
+func pumpNotifications(ws *websocket.Conn, conn *Conn, writeMu *sync.Mutex) {
+	for n := range conn.Notifications() {
+		writeMu.Lock()
+		err := websocket.JSON.Send(ws, n)
+		writeMu.Unlock()
+		if err != nil {
+			glog.V(logger.Debug).Infoln("ws notification write error:", err)
+			return
+		}
+	}
+}
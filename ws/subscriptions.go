@@ -0,0 +1,361 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/xeth"
+)
+
+// Subscription types a client can ask to watch.
+const (
+	SubNewBlocks           = "newBlocks"
+	SubPendingTransactions = "pendingTransactions"
+	SubLogs                = "logs"
+	SubMiningStatus        = "miningStatus"
+)
+
+// FilterCriteria is modeled after xeth's log filter options. Only Logs
+// subscriptions use it; it is ignored for the other subscription types.
+type FilterCriteria struct {
+	FromBlock string     `json:"fromBlock"`
+	ToBlock   string     `json:"toBlock"`
+	Address   []string   `json:"address"`
+	Topics    [][]string `json:"topics"`
+}
+
+type SubscribeRequest struct {
+	Type   string          `json:"type"`
+	Filter json.RawMessage `json:"filter"`
+}
+
+type SubscribeResponse struct {
+	Id string `json:"id"`
+}
+
+type UnsubscribeRequest struct {
+	Id string `json:"id"`
+}
+
+type UnsubscribeResponse struct {
+	Success bool `json:"success"`
+}
+
+// WSNotification is pushed to a subscribed client outside of the normal
+// request/response cycle.
+type WSNotification struct {
+	Method string      `json:"method"`
+	Params interface{} `json:"params"`
+}
+
+// Subscription is a single client's watch on one event feed.
+type Subscription struct {
+	Id     string
+	Type   string
+	Filter *FilterCriteria
+
+	muxSub event.Subscription
+	quit   chan struct{}
+}
+
+var nextSubId uint64
+
+func newSubId() string {
+	return fmt.Sprintf("0x%x", atomic.AddUint64(&nextSubId, 1))
+}
+
+// Conn holds the per-connection state required by the pub/sub subsystem: the
+// subscriptions the client has open and the channel notifications for them
+// are pushed on. The websocket transport drains Notifications() and writes
+// each frame out to the socket.
+type Conn struct {
+	mu            sync.Mutex
+	subscriptions map[string]*Subscription
+	notify        chan *WSNotification
+	closed        bool
+
+	securityToken      string
+	authenticated      bool
+	role               string
+	failedAuthAttempts int
+	authLockedUntil    time.Time
+}
+
+// NewConn creates the per-connection state for a freshly accepted
+// connection. securityToken is the token the client must present via the
+// auth handshake before it may call any ACL-restricted method.
+func NewConn(securityToken string) *Conn {
+	return &Conn{
+		subscriptions: make(map[string]*Subscription),
+		notify:        make(chan *WSNotification, 256),
+		securityToken: securityToken,
+	}
+}
+
+// Notifications returns the channel WSNotification frames are delivered on.
+func (c *Conn) Notifications() <-chan *WSNotification {
+	return c.notify
+}
+
+func (c *Conn) addSubscription(sub *Subscription) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subscriptions[sub.Id] = sub
+}
+
+func (c *Conn) removeSubscription(id string) (*Subscription, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sub, ok := c.subscriptions[id]
+	if ok {
+		delete(c.subscriptions, id)
+	}
+	return sub, ok
+}
+
+// Close tears down every subscription still open on this connection and
+// closes the notify channel, unblocking the transport's pumpNotifications
+// goroutine. It must be called by the transport when the underlying socket
+// goes away; calls after the first do nothing.
+func (c *Conn) Close() {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	var miningStatusSubs []*Subscription
+	for id, sub := range c.subscriptions {
+		close(sub.quit)
+		if sub.muxSub != nil {
+			sub.muxSub.Unsubscribe()
+		}
+		if sub.Type == SubMiningStatus {
+			miningStatusSubs = append(miningStatusSubs, sub)
+		}
+		delete(c.subscriptions, id)
+	}
+	c.closed = true
+	close(c.notify)
+	c.mu.Unlock()
+
+	// Deregistered outside the c.mu critical section, so Close never holds
+	// c.mu and miningSubsMu at the same time; broadcastMiningStatus likewise
+	// releases miningSubsMu before notifyLater takes c.mu.
+	for _, sub := range miningStatusSubs {
+		removeMiningStatusSubscription(sub)
+	}
+}
+
+// notifyLater pushes a notification without blocking the event pump; a
+// client that isn't draining its notify channel loses frames rather than
+// stalling block/tx delivery for everyone else. It is a no-op once Close()
+// has run, so subscription goroutines racing a connection teardown don't
+// panic sending on the now-closed notify channel.
+func (c *Conn) notifyLater(n *WSNotification) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	select {
+	case c.notify <- n:
+	default:
+	}
+}
+
+func subscribe(eth *xeth.XEth, conn *Conn, wsreq *WSRequest, wsres *interface{}) error {
+	var req SubscribeRequest
+	if err := json.Unmarshal(wsreq.Params, &req); err != nil {
+		return err
+	}
+
+	var filter *FilterCriteria
+	if len(req.Filter) > 0 {
+		filter = new(FilterCriteria)
+		if err := json.Unmarshal(req.Filter, filter); err != nil {
+			return err
+		}
+	}
+
+	var muxSub event.Subscription
+	switch req.Type {
+	case SubNewBlocks:
+		muxSub = eth.Backend().EventMux().Subscribe(core.ChainEvent{})
+	case SubPendingTransactions:
+		muxSub = eth.Backend().EventMux().Subscribe(core.TxPreEvent{})
+	case SubLogs:
+		muxSub = eth.Backend().EventMux().Subscribe(core.ChainEvent{}, core.RemovedLogsEvent{})
+	case SubMiningStatus:
+		sub := &Subscription{
+			Id:   newSubId(),
+			Type: req.Type,
+			quit: make(chan struct{}),
+		}
+		conn.addSubscription(sub)
+		addMiningStatusSubscription(conn, sub)
+		*wsres = &SubscribeResponse{Id: sub.Id}
+		return nil
+	default:
+		return UnknownSubscriptionType
+	}
+
+	sub := &Subscription{
+		Id:     newSubId(),
+		Type:   req.Type,
+		Filter: filter,
+		muxSub: muxSub,
+		quit:   make(chan struct{}),
+	}
+	conn.addSubscription(sub)
+	go pumpSubscription(conn, sub)
+
+	*wsres = &SubscribeResponse{Id: sub.Id}
+	return nil
+}
+
+func unsubscribe(eth *xeth.XEth, conn *Conn, wsreq *WSRequest, wsres *interface{}) error {
+	var req UnsubscribeRequest
+	if err := json.Unmarshal(wsreq.Params, &req); err != nil {
+		return err
+	}
+
+	sub, ok := conn.removeSubscription(req.Id)
+	if !ok {
+		return SubscriptionNotFound
+	}
+	close(sub.quit)
+	if sub.muxSub != nil {
+		sub.muxSub.Unsubscribe()
+	}
+	if sub.Type == SubMiningStatus {
+		removeMiningStatusSubscription(sub)
+	}
+
+	*wsres = &UnsubscribeResponse{Success: true}
+	return nil
+}
+
+// miningSubs tracks every open miningStatus subscription. There is no mux
+// feed for mining start/stop (the miner posts none), so minerStart/minerStop
+// broadcast transitions here directly instead of going through
+// pumpSubscription.
+var (
+	miningSubsMu sync.Mutex
+	miningSubs   = map[*Subscription]*Conn{}
+)
+
+func addMiningStatusSubscription(conn *Conn, sub *Subscription) {
+	miningSubsMu.Lock()
+	defer miningSubsMu.Unlock()
+	miningSubs[sub] = conn
+}
+
+func removeMiningStatusSubscription(sub *Subscription) {
+	miningSubsMu.Lock()
+	defer miningSubsMu.Unlock()
+	delete(miningSubs, sub)
+}
+
+// broadcastMiningStatus notifies every open miningStatus subscription of a
+// start/stop transition. The targets are snapshotted and miningSubsMu
+// released before calling notifyLater, since that takes the target Conn's
+// own mutex and Conn.Close acquires the two locks in the opposite order.
+func broadcastMiningStatus(mining bool) {
+	miningSubsMu.Lock()
+	targets := make(map[*Subscription]*Conn, len(miningSubs))
+	for sub, conn := range miningSubs {
+		targets[sub] = conn
+	}
+	miningSubsMu.Unlock()
+
+	for sub, conn := range targets {
+		conn.notifyLater(&WSNotification{Method: sub.Type, Params: mining})
+	}
+}
+
+// pumpSubscription forwards mux events matching sub to conn until the
+// subscription is torn down, either by the client or by Conn.Close().
+func pumpSubscription(conn *Conn, sub *Subscription) {
+	for {
+		select {
+		case evt, ok := <-sub.muxSub.Chan():
+			if !ok {
+				return
+			}
+			if sub.Type == SubLogs {
+				for _, log := range logsFromEvent(evt.Data) {
+					if matchesLogFilter(log, sub.Filter) {
+						conn.notifyLater(&WSNotification{Method: sub.Type, Params: log})
+					}
+				}
+				continue
+			}
+			conn.notifyLater(&WSNotification{Method: sub.Type, Params: evt.Data})
+		case <-sub.quit:
+			return
+		}
+	}
+}
+
+// logsFromEvent extracts the logs carried by a ChainEvent or
+// RemovedLogsEvent payload so a Logs subscription can filter and forward
+// them individually.
+func logsFromEvent(data interface{}) vm.Logs {
+	switch ev := data.(type) {
+	case core.ChainEvent:
+		return ev.Logs
+	case core.RemovedLogsEvent:
+		return ev.Logs
+	default:
+		return nil
+	}
+}
+
+// matchesLogFilter reports whether log satisfies the address/topics
+// restriction of filter. A nil filter, or an empty Address/Topics entry,
+// matches everything for that position.
+func matchesLogFilter(log *vm.Log, filter *FilterCriteria) bool {
+	if filter == nil {
+		return true
+	}
+
+	if len(filter.Address) > 0 {
+		matched := false
+		for _, addr := range filter.Address {
+			if log.Address.Hex() == addr {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	for i, wanted := range filter.Topics {
+		if len(wanted) == 0 {
+			continue
+		}
+		if i >= len(log.Topics) {
+			return false
+		}
+		matched := false
+		for _, topic := range wanted {
+			if log.Topics[i].Hex() == topic {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
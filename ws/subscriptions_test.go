@@ -0,0 +1,49 @@
+package ws
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+func TestMatchesLogFilterNilFilterMatchesEverything(t *testing.T) {
+	log := &vm.Log{Address: common.HexToAddress("0x1")}
+	if !matchesLogFilter(log, nil) {
+		t.Error("expected nil filter to match")
+	}
+}
+
+func TestMatchesLogFilterAddress(t *testing.T) {
+	log := &vm.Log{Address: common.HexToAddress("0x1")}
+	filter := &FilterCriteria{Address: []string{common.HexToAddress("0x2").Hex()}}
+	if matchesLogFilter(log, filter) {
+		t.Error("expected address mismatch to not match")
+	}
+
+	filter.Address = []string{common.HexToAddress("0x1").Hex()}
+	if !matchesLogFilter(log, filter) {
+		t.Error("expected address match to match")
+	}
+}
+
+func TestMatchesLogFilterTopics(t *testing.T) {
+	topic0 := common.HexToHash("0xaa")
+	topic1 := common.HexToHash("0xbb")
+	log := &vm.Log{Topics: []common.Hash{topic0, topic1}}
+
+	filter := &FilterCriteria{Topics: [][]string{{topic0.Hex()}}}
+	if !matchesLogFilter(log, filter) {
+		t.Error("expected matching topic at position 0 to match")
+	}
+
+	filter = &FilterCriteria{Topics: [][]string{{}, {common.HexToHash("0xcc").Hex()}}}
+	if matchesLogFilter(log, filter) {
+		t.Error("expected mismatching topic at position 1 to not match")
+	}
+
+	filter = &FilterCriteria{Topics: [][]string{{}, {}, {topic0.Hex()}}}
+	if matchesLogFilter(log, filter) {
+		t.Error("expected a wanted topic beyond the log's topic count to not match")
+	}
+}
@@ -0,0 +1,17 @@
+package ws
+
+import "crypto/tls"
+
+// TLSConfig builds a *tls.Config from TLSCert/TLSKey. It returns (nil, nil)
+// when TLS is not configured so the transport can serve plain ws in that
+// case.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	if c.TLSCert == "" && c.TLSKey == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.TLSCert, c.TLSKey)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}}, nil
+}
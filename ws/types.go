@@ -1,11 +1,30 @@
 package ws
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+// Protocol selects the wire codec the ws transport speaks.
+const (
+	ProtocolWSJSON   = "wsjson"
+	ProtocolJSONRPC2 = "jsonrpc2"
+)
 
 type Config struct {
 	ListenAddress string
 	ListenPort    uint
 	SecurityToken string
+
+	// TLSCert and TLSKey, if both set, serve the socket over TLS.
+	TLSCert string
+	TLSKey  string
+
+	// Protocol selects the request/response codec: ProtocolWSJSON (the
+	// legacy wsjson framing) or ProtocolJSONRPC2. Defaults to
+	// ProtocolWSJSON when empty.
+	Protocol string
 }
 
 type WSRequest struct {
@@ -35,22 +54,16 @@ type WSErrorObject struct {
 }
 
 type MinerStartRequest struct {
-<<<<<<< HEAD
-    NumThreads int `json:"threads"`
-=======
->>>>>>> 9379d0709d8e0dbadf6b69ef2c31610929e8468b
+	NumThreads int `json:"threads"`
 }
 
 type MinerStartResponse struct {
 }
 
-<<<<<<< HEAD
 type MinerStopRequest struct {
-    NumThreads int `json:"threads"`
+	NumThreads int `json:"threads"`
 }
 
-=======
->>>>>>> 9379d0709d8e0dbadf6b69ef2c31610929e8468b
 type MinerHashrateResponse struct {
 	Hashrate int64 `json:"hashrate"`
 }
@@ -63,3 +76,76 @@ type ImportPresaleWalletRequest struct {
 type ImportPresaleWalletResponse struct {
 	Address string `json:"address"`
 }
+
+type AdminSuggestPeerRequest struct {
+	Url string `json:"url"`
+}
+
+type AdminSuggestPeerResponse struct {
+	Success bool `json:"success"`
+}
+
+type AdminStartRPCRequest struct {
+	ListenAddress string `json:"listenAddress"`
+	ListenPort    uint   `json:"listenPort"`
+	CorsDomain    string `json:"corsDomain"`
+	Apis          string `json:"apis"`
+}
+
+type AdminStartRPCResponse struct {
+	Success bool `json:"success"`
+}
+
+type AdminStopRPCResponse struct {
+	Success bool `json:"success"`
+}
+
+type AdminNodeInfoResponse struct {
+	NodeInfo *p2p.NodeInfo `json:"nodeInfo"`
+}
+
+type AdminPeersResponse struct {
+	Peers []*p2p.PeerInfo `json:"peers"`
+}
+
+type AdminNewAccountRequest struct {
+	Password string `json:"password"`
+}
+
+type AdminNewAccountResponse struct {
+	Address string `json:"address"`
+}
+
+type AdminUnlockRequest struct {
+	Address  string `json:"address"`
+	Password string `json:"password"`
+	Duration int    `json:"duration"`
+}
+
+type AdminUnlockResponse struct {
+	Success bool `json:"success"`
+}
+
+type AdminImportChainRequest struct {
+	File string `json:"file"`
+}
+
+type AdminImportChainResponse struct {
+	Success bool `json:"success"`
+}
+
+type AdminExportChainRequest struct {
+	File string `json:"file"`
+}
+
+type AdminExportChainResponse struct {
+	Success bool `json:"success"`
+}
+
+type AdminDumpBlockRequest struct {
+	Number int64 `json:"number"`
+}
+
+type AdminDumpBlockResponse struct {
+	Dump interface{} `json:"dump"`
+}